@@ -0,0 +1,68 @@
+package task
+
+import "sync"
+
+// subscriberBuffer represents the number of updates a single subscriber
+// channel can queue up before further updates are dropped for it.
+const subscriberBuffer = 4
+
+// broker fans out task updates to any number of subscribers per task ID. It
+// backs Service.Subscribe and lets WaitForFinalStatus be notified as soon as
+// a task's state changes instead of polling Storage on an interval.
+type broker struct {
+	mu          sync.Mutex
+	subscribers map[string][]chan *Task
+}
+
+// newBroker returns a new, empty broker.
+func newBroker() *broker {
+	return &broker{
+		subscribers: map[string][]chan *Task{},
+	}
+}
+
+// subscribe registers a new subscriber for the given task ID and returns the
+// channel updates are delivered on, together with a function that removes the
+// subscription again.
+func (b *broker) subscribe(taskID string) (<-chan *Task, func()) {
+	ch := make(chan *Task, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[taskID] = append(b.subscribers[taskID], ch)
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		channels := b.subscribers[taskID]
+		for i, candidate := range channels {
+			if candidate == ch {
+				b.subscribers[taskID] = append(channels[:i], channels[i+1:]...)
+				break
+			}
+		}
+		if len(b.subscribers[taskID]) == 0 {
+			delete(b.subscribers, taskID)
+		}
+
+		close(ch)
+	}
+
+	return ch, unsubscribe
+}
+
+// publish delivers taskObject to every subscriber currently registered for
+// its ID. A subscriber whose channel is full has the update dropped rather
+// than blocking the publisher.
+func (b *broker) publish(taskObject *Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ch := range b.subscribers[taskObject.ID] {
+		select {
+		case ch <- taskObject:
+		default:
+		}
+	}
+}
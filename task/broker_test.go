@@ -0,0 +1,74 @@
+package task
+
+import "testing"
+
+func TestBrokerPublishDeliversToSubscriber(t *testing.T) {
+	b := newBroker()
+
+	ch, unsubscribe := b.subscribe("task-1")
+	defer unsubscribe()
+
+	taskObject := &Task{ID: "task-1", FinalStatus: StatusSucceeded}
+	b.publish(taskObject)
+
+	select {
+	case received := <-ch:
+		if received != taskObject {
+			t.Fatalf("expected to receive the published task, got %#v", received)
+		}
+	default:
+		t.Fatal("expected a published update to be immediately available")
+	}
+}
+
+func TestBrokerPublishIgnoresUnrelatedTaskID(t *testing.T) {
+	b := newBroker()
+
+	ch, unsubscribe := b.subscribe("task-1")
+	defer unsubscribe()
+
+	b.publish(&Task{ID: "task-2"})
+
+	select {
+	case received := <-ch:
+		t.Fatalf("expected no update for an unrelated task ID, got %#v", received)
+	default:
+	}
+}
+
+func TestBrokerDropsUpdatesOnceSubscriberBufferIsFull(t *testing.T) {
+	b := newBroker()
+
+	ch, unsubscribe := b.subscribe("task-1")
+	defer unsubscribe()
+
+	for i := 0; i < subscriberBuffer+5; i++ {
+		b.publish(&Task{ID: "task-1"})
+	}
+
+	received := 0
+	for {
+		select {
+		case <-ch:
+			received++
+			continue
+		default:
+		}
+		break
+	}
+
+	if received != subscriberBuffer {
+		t.Fatalf("expected exactly %d buffered updates to survive, got %d", subscriberBuffer, received)
+	}
+}
+
+func TestBrokerUnsubscribeClosesChannel(t *testing.T) {
+	b := newBroker()
+
+	ch, unsubscribe := b.subscribe("task-1")
+	unsubscribe()
+
+	if _, ok := <-ch; ok {
+		t.Fatal("expected channel to be closed after unsubscribe")
+	}
+}
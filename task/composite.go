@@ -0,0 +1,229 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/satori/go.uuid"
+
+	"github.com/giantswarm/inago/logging"
+)
+
+// CompositePolicy configures how a composite task created via
+// CreateComposite fans out to its children and how their results are
+// aggregated into the parent's final status.
+type CompositePolicy struct {
+	// Concurrency represents the maximum number of child actions executed at
+	// the same time. A value below 1 means all children run concurrently.
+	Concurrency int
+
+	// FailFast causes the composite task to cancel its remaining children and
+	// finalize as failed as soon as the first child fails, instead of waiting
+	// for every child to reach a final status.
+	FailFast bool
+
+	// Quorum represents the number of children that have to succeed for the
+	// parent task to be marked as succeeded. A value below 1 means every
+	// child has to succeed.
+	Quorum int
+}
+
+// CreateComposite acts as described in the interface comments.
+func (ts *taskService) CreateComposite(actions []Action, policy CompositePolicy) (*Task, error) {
+	concurrency := policy.Concurrency
+	if concurrency < 1 || concurrency > len(actions) {
+		concurrency = len(actions)
+	}
+	sem := make(chan struct{}, concurrency)
+
+	parent := &Task{
+		ID:           uuid.NewV4().String(),
+		ActiveStatus: StatusStarted,
+	}
+	parent.State.Events = append(parent.State.Events, TaskEvent{
+		Type:    "created",
+		Time:    time.Now(),
+		Message: fmt.Sprintf("composite task created with %d children", len(actions)),
+	})
+
+	err := ts.PersistState(parent)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	childIDs := make([]string, 0, len(actions))
+	for _, action := range actions {
+		limitedAction := limitConcurrency(sem, action)
+
+		// ParentID is threaded into create() up front, before the child task
+		// is ever persisted or handed to its executing goroutine. Patching it
+		// onto the *Task returned by Create afterwards would race the same
+		// goroutine concurrently mutating and persisting it as the action
+		// completes.
+		child, err := ts.create(limitedAction, parent.ID)
+		if err != nil {
+			ts.abortComposite(parent, childIDs, err)
+			return nil, maskAny(err)
+		}
+
+		childIDs = append(childIDs, child.ID)
+	}
+
+	parent.ChildIDs = childIDs
+	err = ts.PersistState(parent)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	go ts.awaitComposite(parent.ID, childIDs, policy)
+
+	return parent, nil
+}
+
+// abortComposite is called when fanning out a composite task's children fails
+// partway through. It cancels every child that was already created so none of
+// them keep running untracked, and marks the parent as failed so it does not
+// stay stuck in StatusStarted forever.
+func (ts *taskService) abortComposite(parent *Task, childIDs []string, cause error) {
+	logger := logging.GetLogger()
+
+	for _, childID := range childIDs {
+		err := ts.Cancel(childID)
+		if err != nil {
+			logger.Error(nil, "[E] Composite task: cancelling child '%s' failed: %#v", childID, maskAny(err))
+		}
+	}
+
+	parent.ChildIDs = childIDs
+	_, err := ts.MarkAsFailedWithError(parent, maskAny(fmt.Errorf("failed to create composite task children: %s", cause.Error())))
+	if err != nil {
+		logger.Error(nil, "[E] Composite task: finalizing parent '%s' failed: %#v", parent.ID, maskAny(err))
+	}
+}
+
+// limitConcurrency wraps action so that no more than cap(sem) instances of it
+// run at the same time across a set of composite children. Acquiring the
+// semaphore slot honours ctx.Done(), so a child cancelled by a fail-fast
+// abort while still queued gives up waiting for a slot instead of acquiring
+// one later and running action anyway.
+func limitConcurrency(sem chan struct{}, action Action) Action {
+	return func(ctx context.Context) error {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() { <-sem }()
+
+		return action(ctx)
+	}
+}
+
+// FetchChildren acts as described in the interface comments.
+func (ts *taskService) FetchChildren(taskID string) ([]*Task, error) {
+	parent, err := ts.FetchState(taskID)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	children := make([]*Task, len(parent.ChildIDs))
+	for i, childID := range parent.ChildIDs {
+		child, err := ts.FetchState(childID)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		children[i] = child
+	}
+
+	return children, nil
+}
+
+// awaitComposite waits for every child task to reach a final status and
+// derives the parent task's final status from the result, honouring policy's
+// concurrency, fail-fast and quorum settings.
+func (ts *taskService) awaitComposite(parentID string, childIDs []string, policy CompositePolicy) {
+	logger := logging.GetLogger()
+
+	type childResult struct {
+		index int
+		child *Task
+	}
+
+	closer := make(chan struct{})
+	resultCh := make(chan childResult, len(childIDs))
+
+	for i, childID := range childIDs {
+		go func(i int, childID string) {
+			child, err := ts.WaitForFinalStatus(childID, closer)
+			if err != nil {
+				logger.Error(nil, "[E] Composite task: waiting for child '%s' failed: %#v", childID, maskAny(err))
+				child = nil
+			}
+			resultCh <- childResult{index: i, child: child}
+		}(i, childID)
+	}
+
+	results := make([]*Task, len(childIDs))
+	succeeded := 0
+	failed := 0
+
+	for received := 0; received < len(childIDs); received++ {
+		result := <-resultCh
+		results[result.index] = result.child
+
+		if result.child != nil && result.child.FinalStatus == StatusSucceeded {
+			succeeded++
+		} else {
+			failed++
+		}
+
+		if policy.FailFast && failed > 0 {
+			break
+		}
+	}
+	close(closer)
+
+	failFastTriggered := policy.FailFast && failed > 0
+	if failFastTriggered {
+		for _, childID := range childIDs {
+			err := ts.Cancel(childID)
+			if err != nil {
+				logger.Error(nil, "[E] Composite task: cancelling child '%s' failed: %#v", childID, maskAny(err))
+			}
+		}
+	}
+
+	parent, err := ts.FetchState(parentID)
+	if err != nil {
+		logger.Error(nil, "[E] Composite task: fetching parent '%s' failed: %#v", parentID, maskAny(err))
+		return
+	}
+
+	quorum := policy.Quorum
+	if quorum < 1 {
+		quorum = len(childIDs)
+	}
+
+	// A fail-fast trigger always finalizes the parent as failed, regardless
+	// of how many successes had already arrived before the first failure and
+	// how low Quorum is set. Quorum only decides the outcome when every
+	// child was allowed to run to completion.
+	if succeeded >= quorum && !failFastTriggered {
+		_, err = ts.MarkAsSucceeded(parent)
+	} else {
+		var failures []string
+		for _, child := range results {
+			if child == nil {
+				failures = append(failures, "child result missing")
+			} else if child.FinalStatus != StatusSucceeded {
+				failures = append(failures, child.Error)
+			}
+		}
+		_, err = ts.MarkAsFailedWithError(parent, fmt.Errorf("only %d/%d children succeeded: %s", succeeded, len(childIDs), strings.Join(failures, "; ")))
+	}
+	if err != nil {
+		logger.Error(nil, "[E] Composite task: finalizing parent '%s' failed: %#v", parentID, maskAny(err))
+	}
+}
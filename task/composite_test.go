@@ -0,0 +1,85 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestTaskService() *taskService {
+	config := DefaultConfig()
+	config.WaitSleep = 10 * time.Millisecond
+
+	return NewTaskService(config).(*taskService)
+}
+
+func TestCreateCompositeSucceedsWhenAllChildrenSucceed(t *testing.T) {
+	ts := newTestTaskService()
+
+	actions := []Action{
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error { return nil },
+	}
+
+	parent, err := ts.CreateComposite(actions, CompositePolicy{})
+	if err != nil {
+		t.Fatalf("CreateComposite() failed: %#v", err)
+	}
+
+	final, err := ts.WaitForFinalStatus(parent.ID, nil)
+	if err != nil {
+		t.Fatalf("WaitForFinalStatus() failed: %#v", err)
+	}
+	if final.FinalStatus != StatusSucceeded {
+		t.Fatalf("expected parent FinalStatus '%s', got '%s'", StatusSucceeded, final.FinalStatus)
+	}
+
+	children, err := ts.FetchChildren(parent.ID)
+	if err != nil {
+		t.Fatalf("FetchChildren() failed: %#v", err)
+	}
+	if len(children) != len(actions) {
+		t.Fatalf("expected %d children, got %d", len(actions), len(children))
+	}
+	for _, child := range children {
+		if child.ParentID != parent.ID {
+			t.Fatalf("expected child ParentID '%s', got '%s'", parent.ID, child.ParentID)
+		}
+	}
+}
+
+// TestCreateCompositeFailFastFailsEvenUnderQuorum verifies that a fail-fast
+// trigger always finalizes the parent as failed, even when enough children
+// already succeeded to satisfy a low Quorum.
+func TestCreateCompositeFailFastFailsEvenUnderQuorum(t *testing.T) {
+	ts := newTestTaskService()
+
+	releaseFailure := make(chan struct{})
+	actions := []Action{
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error {
+			<-releaseFailure
+			return errors.New("boom")
+		},
+	}
+
+	parent, err := ts.CreateComposite(actions, CompositePolicy{
+		FailFast: true,
+		Quorum:   1,
+	})
+	if err != nil {
+		t.Fatalf("CreateComposite() failed: %#v", err)
+	}
+
+	close(releaseFailure)
+
+	final, err := ts.WaitForFinalStatus(parent.ID, nil)
+	if err != nil {
+		t.Fatalf("WaitForFinalStatus() failed: %#v", err)
+	}
+	if final.FinalStatus != StatusFailed {
+		t.Fatalf("expected fail-fast to mark the parent '%s' even with Quorum met, got '%s'", StatusFailed, final.FinalStatus)
+	}
+}
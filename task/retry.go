@@ -0,0 +1,86 @@
+package task
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures how a task's action is retried when it fails with a
+// retryable error. Each attempt's delay grows exponentially, bounded by
+// MaxDelay, with a random amount of jitter applied to avoid thundering herd
+// retries against etcd or fleet.
+type RetryPolicy struct {
+	// MaxAttempts represents the maximum number of times an action is executed
+	// before the task is marked as failed. A value of 1 means the action is
+	// not retried at all.
+	MaxAttempts int
+
+	// InitialDelay represents the delay used before the first retry.
+	InitialDelay time.Duration
+
+	// MaxDelay represents the upper bound applied to the computed backoff
+	// delay.
+	MaxDelay time.Duration
+
+	// Multiplier represents the factor the delay grows by after each failed
+	// attempt.
+	Multiplier float64
+
+	// Jitter represents the fraction of the computed delay, between 0 and 1,
+	// that is randomized to spread out retries.
+	Jitter float64
+
+	// IsRetryable decides whether a given error should trigger another
+	// attempt. A nil IsRetryable causes every error to be treated as
+	// retryable.
+	IsRetryable func(err error) bool
+}
+
+// DefaultRetryPolicy returns a best effort retry policy providing a handful of
+// retries with exponential backoff.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:  3,
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     30 * time.Second,
+		Multiplier:   2.0,
+		Jitter:       0.1,
+		IsRetryable:  nil,
+	}
+}
+
+// retryable returns whether the given error should cause another attempt
+// according to the policy.
+func (rp RetryPolicy) retryable(err error) bool {
+	if rp.IsRetryable == nil {
+		return true
+	}
+
+	return rp.IsRetryable(err)
+}
+
+// delayForAttempt computes the backoff delay before the given attempt number,
+// where attempt is 1-indexed for the attempt that just failed.
+func (rp RetryPolicy) delayForAttempt(attempt int) time.Duration {
+	multiplier := rp.Multiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+
+	delay := float64(rp.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if rp.MaxDelay > 0 && delay > float64(rp.MaxDelay) {
+		delay = float64(rp.MaxDelay)
+	}
+
+	if rp.Jitter > 0 {
+		jitterRange := delay * rp.Jitter
+		delay = delay - jitterRange + rand.Float64()*2*jitterRange
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	return time.Duration(delay)
+}
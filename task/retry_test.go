@@ -0,0 +1,173 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelayForAttemptGrowsAndCapsAtMaxDelay(t *testing.T) {
+	rp := RetryPolicy{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     300 * time.Millisecond,
+		Multiplier:   2.0,
+		Jitter:       0,
+	}
+
+	delays := []time.Duration{
+		rp.delayForAttempt(1),
+		rp.delayForAttempt(2),
+		rp.delayForAttempt(3),
+		rp.delayForAttempt(4),
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		300 * time.Millisecond,
+		300 * time.Millisecond,
+	}
+
+	for i, d := range delays {
+		if d != expected[i] {
+			t.Fatalf("delayForAttempt(%d) = %s, want %s", i+1, d, expected[i])
+		}
+	}
+}
+
+func TestRetryPolicyDelayForAttemptAppliesJitterWithinBounds(t *testing.T) {
+	rp := RetryPolicy{
+		InitialDelay: 1 * time.Second,
+		MaxDelay:     1 * time.Second,
+		Multiplier:   1.0,
+		Jitter:       0.5,
+	}
+
+	for i := 0; i < 50; i++ {
+		d := rp.delayForAttempt(1)
+		if d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("delayForAttempt(1) = %s, want within [500ms, 1500ms]", d)
+		}
+	}
+}
+
+func TestRetryPolicyRetryableDefaultsToTrueWithoutPredicate(t *testing.T) {
+	rp := RetryPolicy{}
+
+	if !rp.retryable(errors.New("boom")) {
+		t.Fatal("expected retryable() to default to true when IsRetryable is nil")
+	}
+}
+
+func TestRetryPolicyRetryableUsesPredicate(t *testing.T) {
+	sentinel := errors.New("retryable")
+
+	rp := RetryPolicy{
+		IsRetryable: func(err error) bool {
+			return err == sentinel
+		},
+	}
+
+	if !rp.retryable(sentinel) {
+		t.Fatal("expected retryable() to return true for the sentinel error")
+	}
+	if rp.retryable(errors.New("other")) {
+		t.Fatal("expected retryable() to return false for a non-matching error")
+	}
+}
+
+// TestCreateRetriesTransientFailureThenSucceeds exercises the actual
+// executeWithRetry dispatch loop through Create, rather than the pure
+// delayForAttempt/retryable helpers, asserting a retryable failure is retried
+// with backoff and the task ends up succeeded with one 'attempt-failed' event
+// recorded per failed attempt.
+func TestCreateRetriesTransientFailureThenSucceeds(t *testing.T) {
+	config := DefaultConfig()
+	config.WaitSleep = 10 * time.Millisecond
+	config.Retry = RetryPolicy{
+		MaxAttempts:  3,
+		InitialDelay: 1 * time.Millisecond,
+		MaxDelay:     5 * time.Millisecond,
+		Multiplier:   2.0,
+	}
+	ts := NewTaskService(config)
+
+	var attempts int32
+	taskObject, err := ts.Create(func(ctx context.Context) error {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return errors.New("transient failure")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Create() failed: %#v", err)
+	}
+
+	final, err := ts.WaitForFinalStatus(taskObject.ID, nil)
+	if err != nil {
+		t.Fatalf("WaitForFinalStatus() failed: %#v", err)
+	}
+	if final.FinalStatus != StatusSucceeded {
+		t.Fatalf("expected FinalStatus '%s', got '%s'", StatusSucceeded, final.FinalStatus)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("expected action to run 3 times, got %d", got)
+	}
+
+	attemptFailedEvents := 0
+	for _, event := range final.State.Events {
+		if event.Type == "attempt-failed" {
+			attemptFailedEvents++
+		}
+	}
+	if attemptFailedEvents != 2 {
+		t.Fatalf("expected 2 'attempt-failed' events, got %d", attemptFailedEvents)
+	}
+}
+
+// TestCreateGivesUpAfterMaxAttempts verifies that a persistently failing
+// action is given up on once MaxAttempts is reached, finalizing the task as
+// failed with one 'attempt-failed' event per attempt.
+func TestCreateGivesUpAfterMaxAttempts(t *testing.T) {
+	config := DefaultConfig()
+	config.WaitSleep = 10 * time.Millisecond
+	config.Retry = RetryPolicy{
+		MaxAttempts:  3,
+		InitialDelay: 1 * time.Millisecond,
+		MaxDelay:     5 * time.Millisecond,
+		Multiplier:   2.0,
+	}
+	ts := NewTaskService(config)
+
+	var attempts int32
+	taskObject, err := ts.Create(func(ctx context.Context) error {
+		atomic.AddInt32(&attempts, 1)
+		return errors.New("persistent failure")
+	})
+	if err != nil {
+		t.Fatalf("Create() failed: %#v", err)
+	}
+
+	final, err := ts.WaitForFinalStatus(taskObject.ID, nil)
+	if err != nil {
+		t.Fatalf("WaitForFinalStatus() failed: %#v", err)
+	}
+	if final.FinalStatus != StatusFailed {
+		t.Fatalf("expected FinalStatus '%s', got '%s'", StatusFailed, final.FinalStatus)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("expected action to run 3 times, got %d", got)
+	}
+
+	attemptFailedEvents := 0
+	for _, event := range final.State.Events {
+		if event.Type == "attempt-failed" {
+			attemptFailedEvents++
+		}
+	}
+	if attemptFailedEvents != 3 {
+		t.Fatalf("expected 3 'attempt-failed' events, got %d", attemptFailedEvents)
+	}
+}
@@ -0,0 +1,5 @@
+package task
+
+// StatusCancelled represents a final status indicating that a task's action
+// was cancelled before it could reach a terminal success or failure state.
+const StatusCancelled FinalStatus = "cancelled"
@@ -0,0 +1,187 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// boltBucketName represents the BoltDB bucket task state is stored under.
+var boltBucketName = []byte("inago-tasks")
+
+// minSweepInterval represents the lower bound applied to a BoltStorage's
+// sweep interval, so a very small TaskTTL does not turn the periodic sweep
+// into a busy loop.
+const minSweepInterval = 1 * time.Minute
+
+// BoltStorageConfig represents the configuration used to create a new
+// BoltDB backed Storage.
+type BoltStorageConfig struct {
+	// Path represents the filesystem path of the BoltDB database file.
+	Path string
+
+	// TaskTTL represents how long a completed task's entry is kept around
+	// before it is swept from the database.
+	TaskTTL time.Duration
+
+	// SweepInterval represents how often the database is scanned in the
+	// background for expired, completed tasks to delete. A value below 1
+	// defaults to a quarter of TaskTTL, floored at minSweepInterval.
+	SweepInterval time.Duration
+}
+
+// boltTaskEntry wraps a Task together with the point in time it was last
+// written, so completed entries can be expired after TaskTTL elapses.
+type boltTaskEntry struct {
+	Task      *Task
+	UpdatedAt time.Time
+}
+
+// NewBoltStorage creates a new Storage implementation backed by a BoltDB
+// database at config.Path, with completed tasks expiring after config.TaskTTL.
+func NewBoltStorage(config BoltStorageConfig) (Storage, error) {
+	if config.Path == "" {
+		return nil, maskAny(fmt.Errorf("bolt storage path must not be empty"))
+	}
+
+	taskTTL := config.TaskTTL
+	if taskTTL == 0 {
+		taskTTL = DefaultTaskTTL
+	}
+
+	db, err := bolt.Open(config.Path, 0600, nil)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucketName)
+		return err
+	})
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	sweepInterval := config.SweepInterval
+	if sweepInterval < 1 {
+		sweepInterval = taskTTL / 4
+		if sweepInterval < minSweepInterval {
+			sweepInterval = minSweepInterval
+		}
+	}
+
+	newStorage := &boltStorage{
+		db:      db,
+		taskTTL: taskTTL,
+	}
+
+	go newStorage.sweepLoop(sweepInterval)
+
+	return newStorage, nil
+}
+
+type boltStorage struct {
+	db      *bolt.DB
+	taskTTL time.Duration
+}
+
+// sweepLoop periodically deletes completed tasks whose TaskTTL has elapsed,
+// so storage backed by a fire-and-forget task that is never Get again is
+// still bounded instead of growing the database file forever.
+func (s *boltStorage) sweepLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.sweepExpired()
+	}
+}
+
+func (s *boltStorage) sweepExpired() {
+	var expiredKeys [][]byte
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucketName).ForEach(func(key, raw []byte) error {
+			var entry boltTaskEntry
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				return nil
+			}
+
+			if HasFinalStatus(entry.Task) && time.Since(entry.UpdatedAt) > s.taskTTL {
+				expiredKeys = append(expiredKeys, append([]byte(nil), key...))
+			}
+
+			return nil
+		})
+	})
+	if err != nil || len(expiredKeys) == 0 {
+		return
+	}
+
+	_ = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucketName)
+		for _, key := range expiredKeys {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStorage) Get(taskID string) (*Task, error) {
+	var entry boltTaskEntry
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(boltBucketName).Get([]byte(taskID))
+		if raw == nil {
+			return nil
+		}
+
+		found = true
+		return json.Unmarshal(raw, &entry)
+	})
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if !found {
+		return nil, maskAny(fmt.Errorf("task '%s' not found", taskID))
+	}
+
+	if HasFinalStatus(entry.Task) && time.Since(entry.UpdatedAt) > s.taskTTL {
+		_ = s.delete(taskID)
+		return nil, maskAny(fmt.Errorf("task '%s' not found", taskID))
+	}
+
+	return entry.Task, nil
+}
+
+func (s *boltStorage) Set(taskObject *Task) error {
+	entry := boltTaskEntry{
+		Task:      taskObject,
+		UpdatedAt: time.Now(),
+	}
+
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucketName).Put([]byte(taskObject.ID), raw)
+	})
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
+
+func (s *boltStorage) delete(taskID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucketName).Delete([]byte(taskID))
+	})
+}
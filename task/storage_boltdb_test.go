@@ -0,0 +1,33 @@
+package task
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBoltStorageSweepRemovesExpiredCompletedTasks(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "tasks.db")
+
+	storage, err := NewBoltStorage(BoltStorageConfig{
+		Path:          dbPath,
+		TaskTTL:       10 * time.Millisecond,
+		SweepInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewBoltStorage() failed: %#v", err)
+	}
+	bolt := storage.(*boltStorage)
+
+	taskObject := &Task{ID: "expired-task", FinalStatus: StatusSucceeded}
+	if err := bolt.Set(taskObject); err != nil {
+		t.Fatalf("Set() failed: %#v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = bolt.Get(taskObject.ID)
+	if err == nil {
+		t.Fatal("expected the background sweep to have expired the task without it ever being Get before")
+	}
+}
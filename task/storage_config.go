@@ -0,0 +1,61 @@
+package task
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// DefaultTaskTTL represents the default amount of time a completed task's
+// state is kept around in a persistent Storage backend before it expires.
+const DefaultTaskTTL = 24 * time.Hour
+
+// StorageConfig represents the configuration used to construct a Storage
+// implementation from a URL. The scheme of the URL selects the backend:
+//
+//	mem://                       in-memory storage, state does not survive restarts
+//	bolt:///var/lib/inago/tasks.db   BoltDB file at the given path
+//	etcd://host:2379/prefix     etcd cluster, with the URL path used as key prefix
+type StorageConfig struct {
+	// RawURL represents the URL selecting and configuring the storage backend.
+	RawURL string
+
+	// TaskTTL represents how long completed task state is retained before it
+	// expires. A zero value causes DefaultTaskTTL to be used.
+	TaskTTL time.Duration
+}
+
+// NewStorage returns a new Storage implementation as configured by config.
+func NewStorage(config StorageConfig) (Storage, error) {
+	if config.RawURL == "" {
+		return NewMemoryStorage(), nil
+	}
+
+	taskTTL := config.TaskTTL
+	if taskTTL == 0 {
+		taskTTL = DefaultTaskTTL
+	}
+
+	parsedURL, err := url.Parse(config.RawURL)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	switch parsedURL.Scheme {
+	case "", "mem":
+		return NewMemoryStorage(), nil
+	case "bolt":
+		return NewBoltStorage(BoltStorageConfig{
+			Path:    parsedURL.Path,
+			TaskTTL: taskTTL,
+		})
+	case "etcd":
+		return NewEtcdStorage(EtcdStorageConfig{
+			Endpoints: []string{fmt.Sprintf("http://%s", parsedURL.Host)},
+			Prefix:    parsedURL.Path,
+			TaskTTL:   taskTTL,
+		})
+	default:
+		return nil, maskAny(fmt.Errorf("unknown storage scheme '%s'", parsedURL.Scheme))
+	}
+}
@@ -0,0 +1,152 @@
+package task
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	etcdclient "github.com/coreos/etcd/client"
+	"golang.org/x/net/context"
+)
+
+// defaultEtcdPrefix represents the key prefix used when an EtcdStorageConfig
+// does not specify one explicitly.
+const defaultEtcdPrefix = "/inago/tasks"
+
+// DefaultEtcdEndpoints are used when an EtcdStorageConfig does not specify
+// Endpoints explicitly. They match the etcd endpoints fleet itself falls
+// back to, so task storage talks to the same cluster as the rest of inago
+// without every caller having to re-supply them.
+var DefaultEtcdEndpoints = []string{"http://127.0.0.1:4001"}
+
+// EtcdStorageConfig represents the configuration used to create a new
+// etcd backed Storage.
+type EtcdStorageConfig struct {
+	// Endpoints represents the etcd cluster endpoints to connect to. These
+	// should be the same fleet etcd endpoints used by the rest of inago. A
+	// nil or empty value falls back to DefaultEtcdEndpoints.
+	Endpoints []string
+
+	// Prefix represents the key prefix task state is stored under. Defaults to
+	// "/inago/tasks".
+	Prefix string
+
+	// TaskTTL represents how long a completed task's key is kept around
+	// before etcd expires it.
+	TaskTTL time.Duration
+}
+
+// NewEtcdStorage creates a new Storage implementation backed by etcd, storing
+// each task as JSON under "<prefix>/<id>" with a TTL applied once the task
+// reaches a final status.
+func NewEtcdStorage(config EtcdStorageConfig) (Storage, error) {
+	prefix := config.Prefix
+	if prefix == "" {
+		prefix = defaultEtcdPrefix
+	}
+	prefix = strings.TrimRight(prefix, "/")
+
+	taskTTL := config.TaskTTL
+	if taskTTL == 0 {
+		taskTTL = DefaultTaskTTL
+	}
+
+	endpoints := config.Endpoints
+	if len(endpoints) == 0 {
+		endpoints = DefaultEtcdEndpoints
+	}
+
+	newClient, err := etcdclient.New(etcdclient.Config{
+		Endpoints: endpoints,
+	})
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	newStorage := &etcdStorage{
+		keysAPI: etcdclient.NewKeysAPI(newClient),
+		prefix:  prefix,
+		taskTTL: taskTTL,
+	}
+
+	return newStorage, nil
+}
+
+type etcdStorage struct {
+	keysAPI etcdclient.KeysAPI
+	prefix  string
+	taskTTL time.Duration
+}
+
+func (s *etcdStorage) keyForID(taskID string) string {
+	return s.prefix + "/" + taskID
+}
+
+func (s *etcdStorage) Get(taskID string) (*Task, error) {
+	response, err := s.keysAPI.Get(context.Background(), s.keyForID(taskID), nil)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	taskObject := &Task{}
+	err = json.Unmarshal([]byte(response.Node.Value), taskObject)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return taskObject, nil
+}
+
+// Watch implements Watcher by watching the etcd key the given task is stored
+// under, so WaitForFinalStatus can be notified of changes made by another
+// process sharing the same etcd cluster instead of falling back to polling.
+func (s *etcdStorage) Watch(ctx context.Context, taskID string) (<-chan *Task, error) {
+	watcher := s.keysAPI.Watcher(s.keyForID(taskID), nil)
+
+	ch := make(chan *Task)
+	go func() {
+		defer close(ch)
+
+		for {
+			response, err := watcher.Next(ctx)
+			if err != nil {
+				return
+			}
+			if response.Node == nil || response.Node.Value == "" {
+				continue
+			}
+
+			taskObject := &Task{}
+			if err := json.Unmarshal([]byte(response.Node.Value), taskObject); err != nil {
+				continue
+			}
+
+			select {
+			case ch <- taskObject:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (s *etcdStorage) Set(taskObject *Task) error {
+	raw, err := json.Marshal(taskObject)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	options := &etcdclient.SetOptions{}
+	if HasFinalStatus(taskObject) {
+		options.TTL = s.taskTTL
+	}
+
+	_, err = s.keysAPI.Set(context.Background(), s.keyForID(taskObject.ID), string(raw), options)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package task
+
+import "testing"
+
+// TestAppendEventPreservesOrder verifies that events are appended to a
+// task's state history in the order they are recorded, which is what lets
+// operators read it back as an audit trail of what happened.
+func TestAppendEventPreservesOrder(t *testing.T) {
+	ts := newTestTaskService()
+
+	taskObject := &Task{ID: "task-under-test", ActiveStatus: StatusStarted}
+	if err := ts.PersistState(taskObject); err != nil {
+		t.Fatalf("PersistState() failed: %#v", err)
+	}
+
+	if _, err := ts.AppendEvent(taskObject, TaskEvent{Type: "first"}); err != nil {
+		t.Fatalf("AppendEvent() failed: %#v", err)
+	}
+	if _, err := ts.AppendEvent(taskObject, TaskEvent{Type: "second"}); err != nil {
+		t.Fatalf("AppendEvent() failed: %#v", err)
+	}
+
+	refreshed, err := ts.FetchState(taskObject.ID)
+	if err != nil {
+		t.Fatalf("FetchState() failed: %#v", err)
+	}
+
+	if len(refreshed.State.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(refreshed.State.Events))
+	}
+	if refreshed.State.Events[0].Type != "first" || refreshed.State.Events[1].Type != "second" {
+		t.Fatalf("expected events in recorded order, got %#v", refreshed.State.Events)
+	}
+}
+
+// TestMarkAsSucceededRecordsSucceededEvent verifies that finalizing a task as
+// succeeded automatically appends a matching event to its state history.
+func TestMarkAsSucceededRecordsSucceededEvent(t *testing.T) {
+	ts := newTestTaskService()
+
+	taskObject := &Task{ID: "task-under-test", ActiveStatus: StatusStarted}
+	if err := ts.PersistState(taskObject); err != nil {
+		t.Fatalf("PersistState() failed: %#v", err)
+	}
+
+	refreshed, err := ts.MarkAsSucceeded(taskObject)
+	if err != nil {
+		t.Fatalf("MarkAsSucceeded() failed: %#v", err)
+	}
+
+	lastEvent := refreshed.State.Events[len(refreshed.State.Events)-1]
+	if lastEvent.Type != "succeeded" {
+		t.Fatalf("expected last event to be 'succeeded', got '%s'", lastEvent.Type)
+	}
+}
@@ -1,6 +1,9 @@
 package task
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/satori/go.uuid"
@@ -8,8 +11,37 @@ import (
 	"github.com/giantswarm/inago/logging"
 )
 
-// Action represents any work to be done when executing a task.
-type Action func() error
+// Action represents any work to be done when executing a task. The given
+// context is cancelled when the task is cancelled via Service.Cancel, and
+// implementations are expected to abort as soon as possible once it is done.
+type Action func(ctx context.Context) error
+
+// TaskEvent represents a single, timestamped transition in a task's
+// lifecycle, mirroring Nomad's task state events. Events are appended to a
+// task's State as the task progresses, giving operators an audit trail of
+// what happened during execution rather than just a terminal status.
+type TaskEvent struct {
+	// Type represents the kind of event, e.g. "created", "succeeded" or
+	// "failed".
+	Type string
+
+	// Time represents the point in time the event occured.
+	Time time.Time
+
+	// Message represents a human readable description of the event.
+	Message string
+
+	// Error represents the message of an error associated with the event, if
+	// any.
+	Error string
+}
+
+// TaskState represents the ordered history of events a task went through.
+type TaskState struct {
+	// Events represents the list of events recorded for a task, ordered by
+	// occurrence.
+	Events []TaskEvent
+}
 
 // Task represents a task that is executable.
 type Task struct {
@@ -26,6 +58,17 @@ type Task struct {
 
 	// ID represents the task identifier.
 	ID string
+
+	// State represents the history of events recorded for this task.
+	State TaskState
+
+	// ParentID represents the ID of the composite task this task is a child
+	// of, if any.
+	ParentID string
+
+	// ChildIDs represents the IDs of the child tasks fanned out by this task,
+	// if this task was created using CreateComposite.
+	ChildIDs []string
 }
 
 // Service represents a task managing unit being able to act on task
@@ -36,6 +79,26 @@ type Service interface {
 	// executed asynchronously.
 	Create(action Action) (*Task, error)
 
+	// CreateComposite creates a new parent task fanning out to one child task
+	// per given action. The parent task's final status is derived from its
+	// children according to policy once they all reach a final status
+	// themselves.
+	CreateComposite(actions []Action, policy CompositePolicy) (*Task, error)
+
+	// FetchChildren fetches and returns the current state of every child task
+	// of the composite task with the given ID.
+	FetchChildren(taskID string) ([]*Task, error)
+
+	// Cancel cancels the action running for the given task ID and marks the
+	// corresponding task as cancelled. Cancel is a no-op once the task already
+	// reached a final status.
+	Cancel(taskID string) error
+
+	// AppendEvent appends the given event to the task object's state history
+	// and persists the result. The returned task object is actually the
+	// refreshed version of the provided one.
+	AppendEvent(taskObject *Task, event TaskEvent) (*Task, error)
+
 	// FetchState fetches and returns the current state and status for the given
 	// task ID.
 	FetchState(taskID string) (*Task, error)
@@ -53,6 +116,12 @@ type Service interface {
 	// PersistState writes the given task object to the configured Storage.
 	PersistState(taskObject *Task) error
 
+	// Subscribe returns a channel that receives the given task's object
+	// whenever it changes, along with a function to unsubscribe and release
+	// the channel again. The returned channel is closed once unsubscribe is
+	// called.
+	Subscribe(taskID string) (<-chan *Task, func(), error)
+
 	// WaitForFinalStatus blocks and waits for the given task to reach a final
 	// status. The given closer can end the waiting and thus stop blocking the
 	// call to WaitForFinalStatus.
@@ -64,6 +133,10 @@ type Service interface {
 type Config struct {
 	Storage Storage
 
+	// Retry represents the retry policy applied to a task's action when it
+	// fails with a retryable error.
+	Retry RetryPolicy
+
 	// WaitSleep represents the time to sleep between state-check cycles.
 	WaitSleep time.Duration
 }
@@ -73,6 +146,7 @@ type Config struct {
 func DefaultConfig() Config {
 	newConfig := Config{
 		Storage:   NewMemoryStorage(),
+		Retry:     DefaultRetryPolicy(),
 		WaitSleep: 1 * time.Second,
 	}
 
@@ -82,7 +156,10 @@ func DefaultConfig() Config {
 // NewTaskService returns a new configured task service instance.
 func NewTaskService(config Config) Service {
 	newTaskService := &taskService{
-		Config: config,
+		Config:    config,
+		cancels:   map[string]context.CancelFunc{},
+		broker:    newBroker(),
+		taskLocks: map[string]*sync.Mutex{},
 	}
 
 	return newTaskService
@@ -90,20 +167,59 @@ func NewTaskService(config Config) Service {
 
 type taskService struct {
 	Config
+
+	// cancels tracks the cancel function of every task currently executing
+	// its action, so Cancel can stop it on demand.
+	cancels   map[string]context.CancelFunc
+	cancelsMu sync.Mutex
+
+	// broker fans out task updates to subscribers of WaitForFinalStatus and
+	// Subscribe.
+	broker *broker
+
+	// taskLocks holds one mutex per task ID currently being finalized, so
+	// Cancel and the natural MarkAsSucceeded/MarkAsFailedWithError completion
+	// path cannot race each other into overwriting one another's result.
+	taskLocks   map[string]*sync.Mutex
+	taskLocksMu sync.Mutex
 }
 
 func (ts *taskService) Create(action Action) (*Task, error) {
+	return ts.create(action, "")
+}
+
+// create is the unexported implementation backing both Create and
+// CreateComposite's child fan-out. parentID is set on the task object before
+// it is ever persisted or handed to the executing goroutine, so a child's
+// ParentID never has to be patched in after the fact by a second goroutine
+// racing the one running its action.
+func (ts *taskService) create(action Action, parentID string) (*Task, error) {
 	logger := logging.GetLogger()
 
 	taskObject := &Task{
 		ID:           uuid.NewV4().String(),
 		ActiveStatus: StatusStarted,
 		FinalStatus:  "",
+		ParentID:     parentID,
 	}
+	taskObject.State.Events = append(taskObject.State.Events, TaskEvent{
+		Type:    "created",
+		Time:    time.Now(),
+		Message: "task created",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ts.setCancel(taskObject.ID, cancel)
 
 	go func() {
-		err := action()
+		defer ts.clearCancel(taskObject.ID)
+
+		err := ts.executeWithRetry(ctx, taskObject, action)
 		if err != nil {
+			if err == context.Canceled {
+				return
+			}
+
 			_, markErr := ts.MarkAsFailedWithError(taskObject, err)
 			if markErr != nil {
 				logger.Error(nil, "[E] Task.MarkAsFailed failed: %#v", maskAny(markErr))
@@ -127,33 +243,162 @@ func (ts *taskService) Create(action Action) (*Task, error) {
 	return taskObject, nil
 }
 
-func (ts *taskService) FetchState(taskID string) (*Task, error) {
+// executeWithRetry runs action, retrying it with exponential backoff as long
+// as the returned error is retryable and the configured maximum number of
+// attempts has not been reached. Every attempt is recorded as a state event
+// on taskObject. It returns context.Canceled if ctx is cancelled while
+// waiting for an attempt or its backoff delay.
+func (ts *taskService) executeWithRetry(ctx context.Context, taskObject *Task, action Action) error {
+	maxAttempts := ts.Retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
 	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = action(ctx)
+		if err == nil {
+			return nil
+		}
 
-	taskObject, err := ts.Storage.Get(taskID)
+		if ctx.Err() != nil {
+			return context.Canceled
+		}
+
+		_, appendErr := ts.AppendEvent(taskObject, TaskEvent{
+			Type:    "attempt-failed",
+			Message: fmt.Sprintf("attempt %d/%d failed", attempt, maxAttempts),
+			Error:   err.Error(),
+		})
+		if appendErr != nil {
+			return maskAny(appendErr)
+		}
+
+		if attempt == maxAttempts || !ts.Retry.retryable(err) {
+			break
+		}
+
+		delay := ts.Retry.delayForAttempt(attempt)
+		select {
+		case <-ctx.Done():
+			return context.Canceled
+		case <-time.After(delay):
+		}
+	}
+
+	return maskAny(err)
+}
+
+// Cancel acts as described in the interface comments.
+func (ts *taskService) Cancel(taskID string) error {
+	ts.cancelsMu.Lock()
+	cancel, ok := ts.cancels[taskID]
+	ts.cancelsMu.Unlock()
+
+	if ok {
+		cancel()
+	}
+
+	_, err := ts.finalize(taskID, func(taskObject *Task) {
+		taskObject.ActiveStatus = StatusStopped
+		taskObject.FinalStatus = StatusCancelled
+		taskObject.State.Events = append(taskObject.State.Events, TaskEvent{
+			Type:    "cancelled",
+			Time:    time.Now(),
+			Message: "task cancelled",
+		})
+	})
 	if err != nil {
-		return nil, maskAny(err)
+		return maskAny(err)
 	}
 
-	return taskObject, nil
+	return nil
 }
 
-func (ts *taskService) MarkAsFailedWithError(taskObject *Task, err error) (*Task, error) {
-	taskObject.ActiveStatus = StatusStopped
-	taskObject.Error = err.Error()
-	taskObject.FinalStatus = StatusFailed
+// lockTask returns the mutex guarding finalization of the given task ID,
+// creating it on first use.
+func (ts *taskService) lockTask(taskID string) *sync.Mutex {
+	ts.taskLocksMu.Lock()
+	defer ts.taskLocksMu.Unlock()
+
+	lock, ok := ts.taskLocks[taskID]
+	if !ok {
+		lock = &sync.Mutex{}
+		ts.taskLocks[taskID] = lock
+	}
+
+	return lock
+}
+
+// finalize atomically transitions a task to a final status. It fetches the
+// authoritative, currently persisted copy of the task, applies mutate only if
+// the task has not already reached a final status, and persists the result.
+// If another finalizer (Cancel, MarkAsSucceeded or MarkAsFailedWithError) has
+// already won the race, finalize is a no-op and simply returns the existing
+// final task, so natural completion and cancellation can never silently
+// overwrite one another.
+func (ts *taskService) finalize(taskID string, mutate func(taskObject *Task)) (*Task, error) {
+	lock := ts.lockTask(taskID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	taskObject, err := ts.FetchState(taskID)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	if HasFinalStatus(taskObject) {
+		ts.clearTaskLock(taskID)
+		return taskObject, nil
+	}
+
+	mutate(taskObject)
 
 	err = ts.PersistState(taskObject)
 	if err != nil {
 		return nil, maskAny(err)
 	}
 
+	ts.clearTaskLock(taskID)
+
 	return taskObject, nil
 }
 
-func (ts *taskService) MarkAsSucceeded(taskObject *Task) (*Task, error) {
-	taskObject.ActiveStatus = StatusStopped
-	taskObject.FinalStatus = StatusSucceeded
+func (ts *taskService) setCancel(taskID string, cancel context.CancelFunc) {
+	ts.cancelsMu.Lock()
+	defer ts.cancelsMu.Unlock()
+
+	ts.cancels[taskID] = cancel
+}
+
+func (ts *taskService) clearCancel(taskID string) {
+	ts.cancelsMu.Lock()
+	defer ts.cancelsMu.Unlock()
+
+	delete(ts.cancels, taskID)
+}
+
+// clearTaskLock removes the per-task mutex created by lockTask once a task
+// has reached a final status, so taskLocks does not grow without bound over
+// the lifetime of a long-running daemon. It is safe to call even if another
+// goroutine concurrently creates a fresh lock for the same task ID afterwards
+// (e.g. a racing finalize call already blocked on the old one): finalize
+// always re-checks HasFinalStatus before mutating, so at worst the new lock
+// just serializes a no-op.
+func (ts *taskService) clearTaskLock(taskID string) {
+	ts.taskLocksMu.Lock()
+	defer ts.taskLocksMu.Unlock()
+
+	delete(ts.taskLocks, taskID)
+}
+
+// AppendEvent acts as described in the interface comments.
+func (ts *taskService) AppendEvent(taskObject *Task, event TaskEvent) (*Task, error) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	taskObject.State.Events = append(taskObject.State.Events, event)
 
 	err := ts.PersistState(taskObject)
 	if err != nil {
@@ -163,23 +408,121 @@ func (ts *taskService) MarkAsSucceeded(taskObject *Task) (*Task, error) {
 	return taskObject, nil
 }
 
+func (ts *taskService) FetchState(taskID string) (*Task, error) {
+	var err error
+
+	taskObject, err := ts.Storage.Get(taskID)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	return taskObject, nil
+}
+
+func (ts *taskService) MarkAsFailedWithError(taskObject *Task, err error) (*Task, error) {
+	return ts.finalize(taskObject.ID, func(taskObject *Task) {
+		taskObject.ActiveStatus = StatusStopped
+		taskObject.Error = err.Error()
+		taskObject.FinalStatus = StatusFailed
+		taskObject.State.Events = append(taskObject.State.Events, TaskEvent{
+			Type:    "failed",
+			Time:    time.Now(),
+			Message: "task failed",
+			Error:   err.Error(),
+		})
+	})
+}
+
+func (ts *taskService) MarkAsSucceeded(taskObject *Task) (*Task, error) {
+	return ts.finalize(taskObject.ID, func(taskObject *Task) {
+		taskObject.ActiveStatus = StatusStopped
+		taskObject.FinalStatus = StatusSucceeded
+		taskObject.State.Events = append(taskObject.State.Events, TaskEvent{
+			Type:    "succeeded",
+			Time:    time.Now(),
+			Message: "task succeeded",
+		})
+	})
+}
+
 func (ts *taskService) PersistState(taskObject *Task) error {
 	err := ts.Storage.Set(taskObject)
 	if err != nil {
 		return maskAny(err)
 	}
 
+	ts.broker.publish(taskObject)
+
 	return nil
 }
 
+// Subscribe acts as described in the interface comments.
+func (ts *taskService) Subscribe(taskID string) (<-chan *Task, func(), error) {
+	ch, unsubscribe := ts.broker.subscribe(taskID)
+
+	return ch, unsubscribe, nil
+}
+
 // WaitForFinalStatus acts as described in the interface comments. Note that
 // both, task object and error will be nil in case the closer ends waiting for
 // the task to reach a final state.
+//
+// WaitForFinalStatus is notified of task updates through Subscribe instead of
+// polling Storage, which removes the latency WaitSleep used to add to every
+// call. If the configured Storage also implements Watcher (as etcdStorage
+// does), its watch channel is consulted too, so a task persisted by another
+// process against a shared etcd cluster is noticed immediately as well.
+// WaitSleep is always kept running as a fallback poll alongside either of
+// those, since neither Subscribe nor Watch replay anything that happened
+// before they were registered — relying on them exclusively could otherwise
+// miss a transition that lands in that window and hang forever.
 func (ts *taskService) WaitForFinalStatus(taskID string, closer <-chan struct{}) (*Task, error) {
+	updates, unsubscribe, err := ts.Subscribe(taskID)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	defer unsubscribe()
+
+	var watched <-chan *Task
+	if watcher, ok := ts.Storage.(Watcher); ok {
+		watchCtx, cancelWatch := context.WithCancel(context.Background())
+		defer cancelWatch()
+
+		watched, err = watcher.Watch(watchCtx, taskID)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	// Subscribe and Watch are now both armed, so nothing from this point
+	// onward can be missed. Only now do we check whether the task already
+	// reached a final status — checking beforehand would leave a window in
+	// which a transition happens after the check but before either is
+	// registered, never reaching this call.
+	taskObject, err := ts.FetchState(taskID)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if HasFinalStatus(taskObject) {
+		return taskObject, nil
+	}
+
 	for {
 		select {
 		case <-closer:
 			return nil, nil
+		case taskObject := <-updates:
+			if taskObject != nil && HasFinalStatus(taskObject) {
+				return taskObject, nil
+			}
+		case taskObject, ok := <-watched:
+			if !ok {
+				watched = nil
+				continue
+			}
+			if taskObject != nil && HasFinalStatus(taskObject) {
+				return taskObject, nil
+			}
 		case <-time.After(ts.WaitSleep):
 			taskObject, err := ts.FetchState(taskID)
 			if err != nil {
@@ -0,0 +1,101 @@
+package task
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// TestFinalizeIsANoOpOnceFinal verifies that once a task has reached a final
+// status, a second finalizer (e.g. Cancel racing a naturally succeeding
+// action) never overwrites it.
+func TestFinalizeIsANoOpOnceFinal(t *testing.T) {
+	ts := &taskService{
+		Config: Config{
+			Storage: NewMemoryStorage(),
+		},
+		cancels:   map[string]context.CancelFunc{},
+		broker:    newBroker(),
+		taskLocks: map[string]*sync.Mutex{},
+	}
+
+	taskObject := &Task{
+		ID:           "task-under-test",
+		ActiveStatus: StatusStarted,
+	}
+	if err := ts.PersistState(taskObject); err != nil {
+		t.Fatalf("PersistState() failed: %#v", err)
+	}
+
+	if _, err := ts.MarkAsSucceeded(taskObject); err != nil {
+		t.Fatalf("MarkAsSucceeded() failed: %#v", err)
+	}
+
+	if err := ts.Cancel(taskObject.ID); err != nil {
+		t.Fatalf("Cancel() failed: %#v", err)
+	}
+
+	refreshed, err := ts.FetchState(taskObject.ID)
+	if err != nil {
+		t.Fatalf("FetchState() failed: %#v", err)
+	}
+	if refreshed.FinalStatus != StatusSucceeded {
+		t.Fatalf("expected FinalStatus to stay '%s', got '%s'", StatusSucceeded, refreshed.FinalStatus)
+	}
+}
+
+// TestFinalizeIsConcurrencySafe hammers MarkAsSucceeded, MarkAsFailedWithError
+// and Cancel against the same task concurrently and asserts exactly one of
+// them wins, with the task ending up in a single, consistent final status.
+func TestFinalizeIsConcurrencySafe(t *testing.T) {
+	ts := &taskService{
+		Config: Config{
+			Storage: NewMemoryStorage(),
+		},
+		cancels:   map[string]context.CancelFunc{},
+		broker:    newBroker(),
+		taskLocks: map[string]*sync.Mutex{},
+	}
+
+	taskObject := &Task{
+		ID:           "task-under-test",
+		ActiveStatus: StatusStarted,
+	}
+	if err := ts.PersistState(taskObject); err != nil {
+		t.Fatalf("PersistState() failed: %#v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ts.MarkAsSucceeded(taskObject)
+		done <- struct{}{}
+	}()
+	go func() {
+		ts.Cancel(taskObject.ID)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+
+	refreshed, err := ts.FetchState(taskObject.ID)
+	if err != nil {
+		t.Fatalf("FetchState() failed: %#v", err)
+	}
+	if refreshed.FinalStatus != StatusSucceeded && refreshed.FinalStatus != StatusCancelled {
+		t.Fatalf("expected a single consistent final status, got '%s'", refreshed.FinalStatus)
+	}
+
+	succeededEvents := 0
+	cancelledEvents := 0
+	for _, event := range refreshed.State.Events {
+		switch event.Type {
+		case "succeeded":
+			succeededEvents++
+		case "cancelled":
+			cancelledEvents++
+		}
+	}
+	if succeededEvents+cancelledEvents != 1 {
+		t.Fatalf("expected exactly one terminal event to be recorded, got %d succeeded and %d cancelled", succeededEvents, cancelledEvents)
+	}
+}
@@ -0,0 +1,15 @@
+package task
+
+import "context"
+
+// Watcher is optionally implemented by a Storage backend that can push task
+// updates to interested callers instead of requiring them to poll Get on an
+// interval, such as etcd's native key watch support. WaitForFinalStatus uses
+// it when the configured Storage implements it, and falls back to polling on
+// WaitSleep otherwise.
+type Watcher interface {
+	// Watch returns a channel delivering the task identified by taskID
+	// whenever its stored state changes. The channel is closed once ctx is
+	// done or the watch can no longer be served.
+	Watch(ctx context.Context, taskID string) (<-chan *Task, error)
+}
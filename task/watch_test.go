@@ -0,0 +1,204 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWatcherStorage is an in-memory Storage that also implements Watcher,
+// pushing every Set to any channel currently watching that task ID. It lets
+// tests exercise WaitForFinalStatus's Watcher integration path without an
+// actual etcd cluster.
+type fakeWatcherStorage struct {
+	mu    sync.Mutex
+	tasks map[string]*Task
+	subs  map[string][]chan *Task
+
+	// closeImmediately causes Watch to close its returned channel right away,
+	// simulating a watch that ends before the task reaches a final status, so
+	// WaitForFinalStatus has to fall back to polling.
+	closeImmediately bool
+}
+
+func newFakeWatcherStorage() *fakeWatcherStorage {
+	return &fakeWatcherStorage{
+		tasks: map[string]*Task{},
+		subs:  map[string][]chan *Task{},
+	}
+}
+
+func (s *fakeWatcherStorage) Get(taskID string) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	taskObject, ok := s.tasks[taskID]
+	if !ok {
+		return nil, maskAny(fmt.Errorf("task '%s' not found", taskID))
+	}
+
+	copied := *taskObject
+	return &copied, nil
+}
+
+func (s *fakeWatcherStorage) Set(taskObject *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *taskObject
+	s.tasks[taskObject.ID] = &copied
+
+	for _, ch := range s.subs[taskObject.ID] {
+		select {
+		case ch <- &copied:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeWatcherStorage) Watch(ctx context.Context, taskID string) (<-chan *Task, error) {
+	ch := make(chan *Task, 4)
+
+	if s.closeImmediately {
+		close(ch)
+		return ch, nil
+	}
+
+	s.mu.Lock()
+	s.subs[taskID] = append(s.subs[taskID], ch)
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		subs := s.subs[taskID]
+		for i, c := range subs {
+			if c == ch {
+				s.subs[taskID] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+func newTestTaskServiceWithStorage(storage Storage) *taskService {
+	config := DefaultConfig()
+	config.Storage = storage
+	config.WaitSleep = 20 * time.Millisecond
+
+	return NewTaskService(config).(*taskService)
+}
+
+// TestWaitForFinalStatusDeliversThroughWatch verifies that a task reaching
+// its final status is noticed through the Storage's Watch channel rather than
+// requiring a poll.
+func TestWaitForFinalStatusDeliversThroughWatch(t *testing.T) {
+	storage := newFakeWatcherStorage()
+	ts := newTestTaskServiceWithStorage(storage)
+
+	taskObject := &Task{ID: "task-under-test", ActiveStatus: StatusStarted}
+	if err := ts.PersistState(taskObject); err != nil {
+		t.Fatalf("PersistState() failed: %#v", err)
+	}
+
+	done := make(chan *Task, 1)
+	go func() {
+		final, err := ts.WaitForFinalStatus(taskObject.ID, nil)
+		if err != nil {
+			t.Errorf("WaitForFinalStatus() failed: %#v", err)
+			return
+		}
+		done <- final
+	}()
+
+	// Give WaitForFinalStatus time to register its watch before the
+	// transition is persisted, so delivery exercises the watch channel rather
+	// than the initial FetchState check.
+	time.Sleep(10 * time.Millisecond)
+
+	taskObject.FinalStatus = StatusSucceeded
+	if err := ts.PersistState(taskObject); err != nil {
+		t.Fatalf("PersistState() failed: %#v", err)
+	}
+
+	select {
+	case final := <-done:
+		if final.FinalStatus != StatusSucceeded {
+			t.Fatalf("expected FinalStatus '%s', got '%s'", StatusSucceeded, final.FinalStatus)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForFinalStatus() did not return after the watch delivered the final status")
+	}
+}
+
+// TestWaitForFinalStatusFallsBackToPollWhenWatchEndsEarly verifies that a
+// Watch channel closing before a task reaches its final status does not hang
+// the caller forever, since the fallback poll keeps running alongside it.
+func TestWaitForFinalStatusFallsBackToPollWhenWatchEndsEarly(t *testing.T) {
+	storage := newFakeWatcherStorage()
+	storage.closeImmediately = true
+	ts := newTestTaskServiceWithStorage(storage)
+
+	taskObject := &Task{ID: "task-under-test", ActiveStatus: StatusStarted}
+	if err := ts.PersistState(taskObject); err != nil {
+		t.Fatalf("PersistState() failed: %#v", err)
+	}
+
+	done := make(chan *Task, 1)
+	go func() {
+		final, err := ts.WaitForFinalStatus(taskObject.ID, nil)
+		if err != nil {
+			t.Errorf("WaitForFinalStatus() failed: %#v", err)
+			return
+		}
+		done <- final
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	taskObject.FinalStatus = StatusSucceeded
+	if err := ts.PersistState(taskObject); err != nil {
+		t.Fatalf("PersistState() failed: %#v", err)
+	}
+
+	select {
+	case final := <-done:
+		if final.FinalStatus != StatusSucceeded {
+			t.Fatalf("expected FinalStatus '%s', got '%s'", StatusSucceeded, final.FinalStatus)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForFinalStatus() did not fall back to polling once the watch channel closed")
+	}
+}
+
+// TestWaitForFinalStatusCatchesTransitionAlreadyFinalBeforeCall verifies that
+// a task which already reached its final status before WaitForFinalStatus was
+// ever called is returned immediately, covering the re-check performed right
+// after Subscribe and Watch are armed.
+func TestWaitForFinalStatusCatchesTransitionAlreadyFinalBeforeCall(t *testing.T) {
+	storage := newFakeWatcherStorage()
+	ts := newTestTaskServiceWithStorage(storage)
+
+	taskObject := &Task{ID: "task-under-test", ActiveStatus: StatusStopped, FinalStatus: StatusSucceeded}
+	if err := ts.PersistState(taskObject); err != nil {
+		t.Fatalf("PersistState() failed: %#v", err)
+	}
+
+	final, err := ts.WaitForFinalStatus(taskObject.ID, nil)
+	if err != nil {
+		t.Fatalf("WaitForFinalStatus() failed: %#v", err)
+	}
+	if final.FinalStatus != StatusSucceeded {
+		t.Fatalf("expected FinalStatus '%s', got '%s'", StatusSucceeded, final.FinalStatus)
+	}
+}